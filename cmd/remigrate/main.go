@@ -0,0 +1,387 @@
+// remigrate is a CLI for creating and migrating a RethinkDB database, its
+// tables, and their secondary indexes from a YAML-described desired state.
+// The actual logic lives in github.com/mfridman/remigrate/pkg/remigrate;
+// this package is a thin wrapper around it.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/mfridman/remigrate/pkg/remigrate"
+)
+
+var (
+	ver    = flag.Bool("version", false, "prints current version")
+	config = flag.String("config", "config", "specify config file path relative to the working directory, or an absolute path")
+	drop   = flag.Bool("dbdrop", false, "drop database specified in config file (CAREFUL !!)")
+	plan   = flag.Bool("plan", false, "print the intended database/table/index changes without applying them")
+	prune  = flag.Bool("prune", false, "after confirmation, drop secondary indexes that exist but are no longer declared in config (CAREFUL !!)")
+	watch  = flag.Bool("watch", false, "after applying, stream change events for every configured table to stdout as JSON until interrupted")
+)
+
+// migrateCommands maps golang-migrate-style subcommand names to their
+// handlers. Each handler parses its own flags out of the remaining args.
+var migrateCommands = map[string]func([]string) error{
+	"up":      cmdUp,
+	"down":    cmdDown,
+	"goto":    cmdGoto,
+	"version": cmdVersion,
+	"force":   cmdForce,
+	"drop":    cmdDrop,
+}
+
+func main() {
+	log.SetFlags(0)
+
+	// golang-migrate-style subcommands (up, down N, goto V, version, force
+	// V, drop) take over as soon as one is present as the first argument;
+	// otherwise fall back to the original one-shot "create if missing" flow
+	// for backwards compatibility with existing callers.
+	if len(os.Args) > 1 {
+		if cmd, ok := migrateCommands[os.Args[1]]; ok {
+			if err := cmd(os.Args[2:]); err != nil {
+				log.Fatalln(err)
+			}
+			return
+		}
+	}
+
+	flag.Parse()
+
+	if *ver {
+		fmt.Printf("remigrate version: %s\n", remigrate.VERSION)
+		os.Exit(0)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if *watch {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt)
+		go func() {
+			<-sig
+			cancel()
+		}()
+	}
+
+	cfg, err := remigrate.ReadConfig(*config)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	session, err := remigrate.NewSession(ctx, cfg)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer session.Close()
+
+	if !session.IsConnected() {
+		log.Fatalln("no connection to rethinkDB")
+	}
+
+	mig := remigrate.NewMigrator(session, cfg, nil)
+
+	if *drop {
+		if err := dropWithConfirmation(ctx, mig, cfg.DBName); err != nil {
+			log.Fatalln(err)
+		}
+		os.Exit(0)
+	}
+
+	session.Use(cfg.DBName)
+
+	// decide (Plan) and execute (Apply) both walk the same diff, so
+	// --plan is simply "print the plan instead of running it".
+	actions, err := mig.Plan(ctx)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if *plan {
+		if len(actions) == 0 {
+			fmt.Println("no changes, cluster matches config")
+			return
+		}
+		for _, a := range actions {
+			fmt.Println(a.Description)
+		}
+		return
+	}
+
+	includeDestructive := false
+	if *prune && hasDestructive(actions) {
+		if confirmPrune(actions) {
+			includeDestructive = true
+		} else {
+			log.Println("exiting without pruning drifted indexes")
+		}
+	}
+
+	if err := mig.Apply(ctx, actions, includeDestructive); err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := mig.SeedAll(ctx); err != nil {
+		log.Fatalln(err)
+	}
+
+	stats := mig.Stats()
+	fmt.Printf("---\n%-3d database created\n%-3d table(s) created\n%-3d secondary index(es) created\n",
+		stats.DatabasesCreated, stats.TablesCreated, stats.IndexesCreated)
+
+	if *watch {
+		if err := mig.Watch(ctx, cfg.DBTables, os.Stdout); err != nil && ctx.Err() == nil {
+			log.Fatalln(err)
+		}
+	}
+}
+
+func dropWithConfirmation(ctx context.Context, mig *remigrate.Migrator, dbName string) error {
+	if !confirmDrop(dbName) {
+		log.Printf("exiting without dropping database [%s]\n", dbName)
+		return nil
+	}
+	return mig.Drop(ctx)
+}
+
+// hasDestructive reports whether actions contains any destructive action.
+func hasDestructive(actions []remigrate.Action) bool {
+	for _, a := range actions {
+		if a.Destructive {
+			return true
+		}
+	}
+	return false
+}
+
+// confirmPrune prompts for confirmation before dropping any drifted
+// indexes, listing exactly which ones, mirroring confirmDrop's ergonomics
+// for the --dbdrop flag.
+func confirmPrune(actions []remigrate.Action) bool {
+	var destructive []remigrate.Action
+	for _, a := range actions {
+		if a.Destructive {
+			destructive = append(destructive, a)
+		}
+	}
+
+	fmt.Println("the following indexes are not declared in config and would be dropped:")
+	for _, a := range destructive {
+		fmt.Printf("  %s\n", a.Description)
+	}
+
+	bufnr := bufio.NewReader(os.Stdin)
+	for i := 3; i > 0; i-- {
+		fmt.Print("are you sure you want to drop these indexes [y/n]: ")
+		r, err := bufnr.ReadString('\n')
+		if err != nil {
+			log.Fatalln(err)
+		}
+		r = strings.ToLower(strings.TrimSpace(r))
+		switch r {
+		case "yes", "y":
+			return true
+		case "no", "n":
+			return false
+		}
+	}
+	return false
+}
+
+func confirmDrop(dbName string) bool {
+	bufnr := bufio.NewReader(os.Stdin)
+	for i := 3; i > 0; i-- {
+		fmt.Printf("are you sure you want to drop the [%s] database [y/n]: ", dbName)
+		r, err := bufnr.ReadString('\n')
+		if err != nil {
+			log.Fatalln(err)
+		}
+		r = strings.ToLower(strings.TrimSpace(r))
+		switch r {
+		case "yes", "y":
+			return true
+		case "no", "n":
+			return false
+		}
+	}
+	return false
+}
+
+// migrateFlags returns a FlagSet shared by the migrate subcommands, along
+// with the parsed config and migrations directory flags.
+func migrateFlags(name string, args []string) (*flag.FlagSet, *string, *string, error) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	cfgPath := fs.String("config", "config", "specify config file path relative to the working directory, or an absolute path")
+	migrationsDir := fs.String("migrations", "migrations", "path to the directory of migration files")
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, nil, err
+	}
+	return fs, cfgPath, migrationsDir, nil
+}
+
+// connectAndUse reads the config, opens a session, and selects cfg.DBName.
+func connectAndUse(ctx context.Context, cfgPath string) (*remigrate.Migrator, error) {
+	cfg, err := remigrate.ReadConfig(cfgPath)
+	if err != nil {
+		return nil, err
+	}
+	session, err := remigrate.NewSession(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	session.Use(cfg.DBName)
+	return remigrate.NewMigrator(session, cfg, nil), nil
+}
+
+func cmdUp(args []string) error {
+	_, cfgPath, migrationsDir, err := migrateFlags("up", args)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	mig, err := connectAndUse(ctx, *cfgPath)
+	if err != nil {
+		return err
+	}
+	defer mig.Close()
+
+	migrations, err := remigrate.LoadMigrations(*migrationsDir)
+	if err != nil {
+		return err
+	}
+	return mig.Up(ctx, migrations)
+}
+
+func cmdDown(args []string) error {
+	fs, cfgPath, migrationsDir, err := migrateFlags("down", args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: remigrate down N")
+	}
+	n, err := strconv.Atoi(fs.Arg(0))
+	if err != nil || n <= 0 {
+		return fmt.Errorf("N must be a positive integer")
+	}
+
+	ctx := context.Background()
+	mig, err := connectAndUse(ctx, *cfgPath)
+	if err != nil {
+		return err
+	}
+	defer mig.Close()
+
+	migrations, err := remigrate.LoadMigrations(*migrationsDir)
+	if err != nil {
+		return err
+	}
+	return mig.Down(ctx, migrations, n)
+}
+
+func cmdGoto(args []string) error {
+	fs, cfgPath, migrationsDir, err := migrateFlags("goto", args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: remigrate goto V")
+	}
+	target, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("V must be an integer version")
+	}
+
+	ctx := context.Background()
+	mig, err := connectAndUse(ctx, *cfgPath)
+	if err != nil {
+		return err
+	}
+	defer mig.Close()
+
+	migrations, err := remigrate.LoadMigrations(*migrationsDir)
+	if err != nil {
+		return err
+	}
+	return mig.Goto(ctx, migrations, target)
+}
+
+func cmdVersion(args []string) error {
+	_, cfgPath, _, err := migrateFlags("version", args)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	mig, err := connectAndUse(ctx, *cfgPath)
+	if err != nil {
+		return err
+	}
+	defer mig.Close()
+
+	if err := mig.EnsureSchemaTables(ctx); err != nil {
+		return err
+	}
+	version, dirty, err := mig.CurrentVersion(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d", version)
+	if dirty {
+		fmt.Print(" (dirty)")
+	}
+	fmt.Println()
+	return nil
+}
+
+func cmdForce(args []string) error {
+	fs, cfgPath, _, err := migrateFlags("force", args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: remigrate force V")
+	}
+	version, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("V must be an integer version")
+	}
+
+	ctx := context.Background()
+	mig, err := connectAndUse(ctx, *cfgPath)
+	if err != nil {
+		return err
+	}
+	defer mig.Close()
+
+	if err := mig.Force(ctx, version); err != nil {
+		return err
+	}
+	log.Printf("forced schema version to %d, dirty flag cleared\n", version)
+	return nil
+}
+
+func cmdDrop(args []string) error {
+	_, cfgPath, _, err := migrateFlags("drop", args)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	mig, err := connectAndUse(ctx, *cfgPath)
+	if err != nil {
+		return err
+	}
+	defer mig.Close()
+
+	return dropWithConfirmation(ctx, mig, mig.Config.DBName)
+}
@@ -0,0 +1,77 @@
+// Package remigrate creates and migrates RethinkDB databases, tables, and
+// secondary indexes from a YAML-described desired state, and applies
+// versioned up/down migrations on top of that state.
+package remigrate
+
+// Config represents necessary info for establishing a connection to
+// rethinkdb and creating the db structure. The database, tables and indexes
+// are created if non-existent.
+type Config struct {
+	// DBIP and DBPort are used to build a single-address connection when
+	// Addresses is empty. Kept for backwards compatibility with existing
+	// config files.
+	DBIP   string `yaml:"ip"`
+	DBPort string `yaml:"port"`
+
+	// Addresses, when set, takes precedence over DBIP/DBPort and is passed
+	// straight to gorethink so it can discover the rest of a cluster, or so
+	// it can talk to a rethinkdb-proxy fronting one.
+	Addresses []string `yaml:"addresses"`
+
+	// AuthKey authenticates against clusters with the legacy auth key set.
+	// Username/Password authenticate against clusters with user accounts.
+	AuthKey  string `yaml:"auth_key"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	// TLS, if set, enables a TLS connection using the given PEM-encoded
+	// CA certificate to verify the server.
+	TLS *TLSConfig `yaml:"tls"`
+
+	// ConnectRetries is the number of times to attempt the initial
+	// connection before giving up. ConnectRetryWait is the delay between
+	// attempts. Both default to sane values if left unset.
+	ConnectRetries   int `yaml:"connect_retries"`
+	ConnectRetryWait int `yaml:"connect_retry_wait_seconds"`
+
+	// DBName must contain alphanumeric characters and underscores
+	DBName   string  `yaml:"database_name"`
+	DBTables []Table `yaml:"tables"`
+}
+
+// TLSConfig points at a CA certificate used to verify the RethinkDB server.
+type TLSConfig struct {
+	CACertFile string `yaml:"ca_cert_file"`
+}
+
+// A Table represents the metada of a rethinkdb table.
+type Table struct {
+	// Name represents the name of the table.
+	Name string `yaml:"name"`
+
+	// The name of the primary key. If left blank the default primary key is set to id.
+	PrimaryKey string `yaml:"primary_key"`
+
+	// Shards and Replicas control how the table is provisioned across the
+	// cluster. Both default to RethinkDB's own defaults (1 shard, 1
+	// replica) when left at zero.
+	Shards   int `yaml:"shards"`
+	Replicas int `yaml:"replicas"`
+
+	// PrimaryReplicaTag pins the primary replica to a server tag, letting
+	// callers provision tables that prefer a particular datacenter/rack.
+	PrimaryReplicaTag string `yaml:"primary_replica_tag"`
+
+	// Secondary index(es) on a table expressed as bare field names.
+	// https://www.rethinkdb.com/docs/secondary-indexes
+	SimpleIndexes []string `yaml:"simple_index"`
+
+	// Indexes holds compound, multi, geo, and function-based secondary
+	// indexes; see index.go.
+	Indexes []Index `yaml:"indexes"`
+
+	// Seed holds fixture documents to upsert into the table once it and its
+	// indexes are ready; see seed.go. Each document must carry the table's
+	// primary key field so the upsert is idempotent.
+	Seed []map[string]interface{} `yaml:"seed"`
+}
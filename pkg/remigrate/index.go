@@ -0,0 +1,126 @@
+package remigrate
+
+import (
+	"context"
+
+	r "github.com/GoRethink/gorethink"
+	"github.com/pkg/errors"
+)
+
+// IndexFunc is a tiny DSL for expressing a secondary index as a RethinkDB
+// row term rather than a bare field name, e.g. `function: {lower: "name"}`
+// compiles to r.Row.Field("name").Downcase().
+type IndexFunc struct {
+	Lower string `yaml:"lower,omitempty"`
+}
+
+// compile turns the DSL into the r.Term RethinkDB expects for
+// IndexCreateFunc.
+func (f IndexFunc) compile() (r.Term, error) {
+	if f.Lower != "" {
+		return r.Row.Field(f.Lower).Downcase(), nil
+	}
+	return r.Term{}, errors.New("index function must set one of: lower")
+}
+
+// Index is a secondary index beyond what SimpleIndexes can express:
+// compound (Fields has more than one entry), multi (over each element of an
+// array field), geo (over r.geojson points), or an arbitrary function of
+// the row.
+type Index struct {
+	Name string `yaml:"name"`
+
+	// Fields backs a simple (one entry) or compound (multiple entries) index.
+	Fields []string `yaml:"fields"`
+
+	// Multi indexes over each element of an array field/fields result.
+	Multi bool `yaml:"multi"`
+
+	// Geo marks the index for use with geospatial queries.
+	Geo bool `yaml:"geo"`
+
+	// Function, if set, takes precedence over Fields and defines the index
+	// as an arbitrary term via the IndexFunc DSL.
+	Function *IndexFunc `yaml:"function"`
+}
+
+// indexStatusRow mirrors the subset of IndexStatus's response remigrate cares
+// about.
+type indexStatusRow struct {
+	Index string `gorethink:"index"`
+	Ready bool   `gorethink:"ready"`
+}
+
+// indexStatus returns the set of secondary index names that currently exist
+// on tblName.
+func (m *Migrator) indexStatus(ctx context.Context, tblName string) (map[string]bool, error) {
+	var rows []indexStatusRow
+	if err := m.Session.readAll(ctx, r.Table(tblName).IndexStatus(), &rows); err != nil {
+		return nil, errors.Wrapf(err, "could not read index status for table [%s]", tblName)
+	}
+	lookup := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		lookup[row.Index] = true
+	}
+	return lookup, nil
+}
+
+// addIndexes creates every declared Index on table that doesn't already
+// exist, via IndexCreateFunc for compound/multi/geo/function indexes.
+func (m *Migrator) addIndexes(ctx context.Context, table Table) error {
+	if len(table.Indexes) == 0 {
+		return nil
+	}
+
+	existing, err := m.indexStatus(ctx, table.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, idx := range table.Indexes {
+		if existing[idx.Name] {
+			m.Logger.Printf("[%-30s] %-10s secondary index exists on %s\n", idx.Name, ignore, table.Name)
+			continue
+		}
+		if err := m.indexCreateSpec(ctx, table.Name, idx); err != nil {
+			return err
+		}
+		m.Logger.Printf("[%-30s] %-10s secondary index on %s\n", idx.Name, create, table.Name)
+	}
+	return nil
+}
+
+// indexCreateSpec creates a single Index, dispatching to IndexCreateFunc
+// whenever the index needs more than RethinkDB's plain field-name form.
+func (m *Migrator) indexCreateSpec(ctx context.Context, tblName string, idx Index) error {
+	opts := r.IndexCreateOpts{Multi: idx.Multi, Geo: idx.Geo}
+
+	var term r.Term
+	switch {
+	case idx.Function != nil:
+		fn, err := idx.Function.compile()
+		if err != nil {
+			return errors.Wrapf(err, "invalid function for [%s] index on table [%s]", idx.Name, tblName)
+		}
+		term = fn
+	case len(idx.Fields) == 1:
+		term = r.Row.Field(idx.Fields[0])
+	case len(idx.Fields) > 1:
+		fields := make([]interface{}, len(idx.Fields))
+		for i, f := range idx.Fields {
+			fields[i] = r.Row.Field(f)
+		}
+		term = r.Expr(fields)
+	default:
+		return errors.Errorf("index [%s] on table [%s] declares neither fields nor a function", idx.Name, tblName)
+	}
+
+	if _, err := m.Session.runWrite(ctx, r.Table(tblName).IndexCreateFunc(idx.Name, term, opts)); err != nil {
+		return errors.Wrapf(err, "failed to create [%s] secondary index on table [%s]", idx.Name, tblName)
+	}
+	if _, err := m.Session.runWrite(ctx, r.Table(tblName).IndexWait(idx.Name)); err != nil {
+		return errors.Wrapf(err, "failed waiting for [%s] secondary index on table [%s] to become ready", idx.Name, tblName)
+	}
+	m.stats.IndexesCreated++
+	return nil
+}
@@ -0,0 +1,20 @@
+package remigrate
+
+import "testing"
+
+func TestIndexFuncCompile(t *testing.T) {
+	f := IndexFunc{Lower: "name"}
+	term, err := f.compile()
+	if err != nil {
+		t.Fatalf("compile returned error: %v", err)
+	}
+	if got := term.String(); got == "" {
+		t.Fatal("compile returned an empty term")
+	}
+}
+
+func TestIndexFuncCompileEmpty(t *testing.T) {
+	if _, err := (IndexFunc{}).compile(); err == nil {
+		t.Fatal("expected an error for an IndexFunc with no operation set, got nil")
+	}
+}
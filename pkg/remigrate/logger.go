@@ -0,0 +1,19 @@
+package remigrate
+
+import "log"
+
+// Logger is the logging hook a Migrator reports progress through, so
+// embedders can route it into their own logging rather than remigrate
+// writing to stdout/stderr directly.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// StdLogger adapts the standard library's log package to the Logger
+// interface; it's the default used by the cmd/remigrate CLI.
+type StdLogger struct{}
+
+// Printf implements Logger.
+func (StdLogger) Printf(format string, args ...interface{}) {
+	log.Printf(format, args...)
+}
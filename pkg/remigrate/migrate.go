@@ -0,0 +1,161 @@
+package remigrate
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Up applies every migration in migrations newer than the current schema
+// version, in order.
+func (m *Migrator) Up(ctx context.Context, migrations []Migration) error {
+	if err := m.EnsureSchemaTables(ctx); err != nil {
+		return err
+	}
+	if err := m.GuardDirty(ctx); err != nil {
+		return err
+	}
+
+	if err := m.Lock(ctx); err != nil {
+		return err
+	}
+	defer m.Unlock(ctx)
+
+	// Re-read the version now that the lock is held, not before: a second
+	// process could have raced us between GuardDirty and Lock, migrated,
+	// and released the lock, leaving our pre-lock read stale.
+	current, _, err := m.CurrentVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, mig := range migrations {
+		if mig.Version <= current {
+			continue
+		}
+		if err := m.SetVersion(ctx, mig.Version, true); err != nil {
+			return err
+		}
+		if err := m.applyOperations(ctx, mig.Up); err != nil {
+			return errors.Wrapf(err, "migration %d left database dirty", mig.Version)
+		}
+		if err := m.SetVersion(ctx, mig.Version, false); err != nil {
+			return err
+		}
+		m.Logger.Printf("[%-30d] %-10s migration applied\n", mig.Version, create)
+	}
+	return nil
+}
+
+// Down rolls back up to n of the most recently applied migrations.
+func (m *Migrator) Down(ctx context.Context, migrations []Migration, n int) error {
+	if err := m.EnsureSchemaTables(ctx); err != nil {
+		return err
+	}
+	if err := m.GuardDirty(ctx); err != nil {
+		return err
+	}
+
+	if err := m.Lock(ctx); err != nil {
+		return err
+	}
+	defer m.Unlock(ctx)
+
+	// Re-read the version now that the lock is held; see Up for why.
+	current, _, err := m.CurrentVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	applied := 0
+	for i := len(migrations) - 1; i >= 0 && applied < n; i-- {
+		mig := migrations[i]
+		if mig.Version > current {
+			continue
+		}
+		if err := m.SetVersion(ctx, mig.Version, true); err != nil {
+			return err
+		}
+		if err := m.applyOperations(ctx, mig.Down); err != nil {
+			return errors.Wrapf(err, "migration %d left database dirty", mig.Version)
+		}
+		previous := previousVersion(migrations, mig.Version)
+		if err := m.SetVersion(ctx, previous, false); err != nil {
+			return err
+		}
+		m.Logger.Printf("[%-30d] %-10s migration rolled back\n", mig.Version, "down")
+		current = previous
+		applied++
+	}
+	return nil
+}
+
+// Goto migrates up or down, as needed, to land exactly on target.
+func (m *Migrator) Goto(ctx context.Context, migrations []Migration, target int64) error {
+	if err := m.EnsureSchemaTables(ctx); err != nil {
+		return err
+	}
+	if err := m.GuardDirty(ctx); err != nil {
+		return err
+	}
+
+	if err := m.Lock(ctx); err != nil {
+		return err
+	}
+	defer m.Unlock(ctx)
+
+	// Re-read the version now that the lock is held; see Up for why.
+	current, _, err := m.CurrentVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if target > current {
+		for _, mig := range migrations {
+			if mig.Version <= current || mig.Version > target {
+				continue
+			}
+			if err := m.SetVersion(ctx, mig.Version, true); err != nil {
+				return err
+			}
+			if err := m.applyOperations(ctx, mig.Up); err != nil {
+				return errors.Wrapf(err, "migration %d left database dirty", mig.Version)
+			}
+			if err := m.SetVersion(ctx, mig.Version, false); err != nil {
+				return err
+			}
+			m.Logger.Printf("[%-30d] %-10s migration applied\n", mig.Version, create)
+		}
+		return nil
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		mig := migrations[i]
+		if mig.Version > current || mig.Version <= target {
+			continue
+		}
+		if err := m.SetVersion(ctx, mig.Version, true); err != nil {
+			return err
+		}
+		if err := m.applyOperations(ctx, mig.Down); err != nil {
+			return errors.Wrapf(err, "migration %d left database dirty", mig.Version)
+		}
+		previous := previousVersion(migrations, mig.Version)
+		if err := m.SetVersion(ctx, previous, false); err != nil {
+			return err
+		}
+		m.Logger.Printf("[%-30d] %-10s migration rolled back\n", mig.Version, "down")
+	}
+	return nil
+}
+
+// Force sets the schema version directly and clears the dirty flag,
+// without applying any migration. It's the recovery path for a dirty
+// database: an operator fixes the schema by hand, then tells remigrate
+// what version that leaves it at.
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if err := m.EnsureSchemaTables(ctx); err != nil {
+		return err
+	}
+	return m.SetVersion(ctx, version, false)
+}
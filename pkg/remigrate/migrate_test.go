@@ -0,0 +1,138 @@
+package remigrate
+
+import (
+	"context"
+	"testing"
+)
+
+var _ session = (*fakeSession)(nil)
+
+func newTestMigrator() *Migrator {
+	return &Migrator{
+		Session: newFakeSession(),
+		Config:  &Config{DBName: "testdb"},
+		Logger:  nullLogger{},
+	}
+}
+
+func TestLockThenUnlock(t *testing.T) {
+	ctx := context.Background()
+	m := newTestMigrator()
+
+	if err := m.Lock(ctx); err != nil {
+		t.Fatalf("Lock returned error: %v", err)
+	}
+	if err := m.Lock(ctx); err == nil {
+		t.Fatal("expected a second Lock to fail while the first is held")
+	}
+	if err := m.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock returned error: %v", err)
+	}
+	if err := m.Lock(ctx); err != nil {
+		t.Fatalf("Lock after Unlock returned error: %v", err)
+	}
+}
+
+func TestCurrentVersionNoMigrationsApplied(t *testing.T) {
+	ctx := context.Background()
+	m := newTestMigrator()
+
+	version, dirty, err := m.CurrentVersion(ctx)
+	if err != nil {
+		t.Fatalf("CurrentVersion returned error: %v", err)
+	}
+	if version != 0 || dirty {
+		t.Fatalf("expected (0, false) for a fresh schema, got (%d, %v)", version, dirty)
+	}
+}
+
+func TestUpAppliesEveryNewerMigration(t *testing.T) {
+	ctx := context.Background()
+	m := newTestMigrator()
+
+	migrations := []Migration{
+		{Version: 1, Up: []Operation{{CreateTable: &Table{Name: "users"}}}, Down: []Operation{{DropTable: "users"}}},
+		{Version: 2, Up: []Operation{{CreateTable: &Table{Name: "orders"}}}, Down: []Operation{{DropTable: "orders"}}},
+	}
+
+	if err := m.Up(ctx, migrations); err != nil {
+		t.Fatalf("Up returned error: %v", err)
+	}
+
+	version, dirty, err := m.CurrentVersion(ctx)
+	if err != nil {
+		t.Fatalf("CurrentVersion returned error: %v", err)
+	}
+	if version != 2 || dirty {
+		t.Fatalf("expected (2, false) after Up, got (%d, %v)", version, dirty)
+	}
+
+	// Running Up again with the same migrations must be a no-op: every
+	// migration is already at or below the current version.
+	if err := m.Up(ctx, migrations); err != nil {
+		t.Fatalf("second Up returned error: %v", err)
+	}
+	version, _, err = m.CurrentVersion(ctx)
+	if err != nil {
+		t.Fatalf("CurrentVersion returned error: %v", err)
+	}
+	if version != 2 {
+		t.Fatalf("expected a second Up to leave version at 2, got %d", version)
+	}
+}
+
+func TestDownRollsBackMostRecentlyApplied(t *testing.T) {
+	ctx := context.Background()
+	m := newTestMigrator()
+
+	migrations := []Migration{
+		{Version: 1, Up: []Operation{{CreateTable: &Table{Name: "users"}}}, Down: []Operation{{DropTable: "users"}}},
+		{Version: 2, Up: []Operation{{CreateTable: &Table{Name: "orders"}}}, Down: []Operation{{DropTable: "orders"}}},
+	}
+
+	if err := m.Up(ctx, migrations); err != nil {
+		t.Fatalf("Up returned error: %v", err)
+	}
+	if err := m.Down(ctx, migrations, 1); err != nil {
+		t.Fatalf("Down returned error: %v", err)
+	}
+
+	version, dirty, err := m.CurrentVersion(ctx)
+	if err != nil {
+		t.Fatalf("CurrentVersion returned error: %v", err)
+	}
+	if version != 1 || dirty {
+		t.Fatalf("expected (1, false) after rolling back one migration, got (%d, %v)", version, dirty)
+	}
+}
+
+// TestUpReReadsVersionAfterLock checks that Up starts from the version
+// already recorded in schema_migrations and applies only newer migrations.
+// SetVersion runs before Up is called, so the test passes whether Up reads
+// the version before or after Lock; it does not interleave a second process
+// between those two steps.
+func TestUpReReadsVersionAfterLock(t *testing.T) {
+	ctx := context.Background()
+	m := newTestMigrator()
+
+	if err := m.SetVersion(ctx, 1, false); err != nil {
+		t.Fatalf("SetVersion returned error: %v", err)
+	}
+
+	migrations := []Migration{
+		{Version: 1, Up: []Operation{{CreateTable: &Table{Name: "users"}}}, Down: []Operation{{DropTable: "users"}}},
+		{Version: 2, Up: []Operation{{CreateTable: &Table{Name: "orders"}}}, Down: []Operation{{DropTable: "orders"}}},
+	}
+
+	if err := m.Up(ctx, migrations); err != nil {
+		t.Fatalf("Up returned error: %v", err)
+	}
+
+	version, _, err := m.CurrentVersion(ctx)
+	if err != nil {
+		t.Fatalf("CurrentVersion returned error: %v", err)
+	}
+	if version != 2 {
+		t.Fatalf("expected Up to see the version set before it ran and only apply migration 2, got version %d", version)
+	}
+}
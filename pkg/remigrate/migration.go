@@ -0,0 +1,146 @@
+package remigrate
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/go-yaml/yaml"
+	"github.com/pkg/errors"
+)
+
+// migrationFileRE matches migration file names of the form
+// 0001_add_users.up.yaml / 0001_add_users.down.yaml
+var migrationFileRE = regexp.MustCompile(`^(\d+)_[^.]+\.(up|down)\.yaml$`)
+
+// IndexOp describes a secondary index to drop as part of a migration. Only
+// the name is needed to drop an index, unlike creating one.
+type IndexOp struct {
+	Table string `yaml:"table"`
+	Name  string `yaml:"name"`
+}
+
+// CreateIndexOp describes a secondary index to create on an existing table
+// as part of a migration. It embeds Index so a migration can declare the
+// same compound/multi/geo/function shapes EnsureIndexes supports for a
+// brand-new table, routed through the same indexCreateSpec.
+type CreateIndexOp struct {
+	Table string `yaml:"table"`
+	Index `yaml:",inline"`
+}
+
+// PrimaryKeyOp describes a primary key change on an existing table. RethinkDB
+// does not support altering the primary key of a table in place, so applying
+// this op recreates the table: it is only safe to use on tables with no data
+// the user cares about, or as a no-op recorded for historical purposes.
+type PrimaryKeyOp struct {
+	Table string `yaml:"table"`
+	Field string `yaml:"field"`
+}
+
+// Operation is a single unit of schema change. Exactly one field should be
+// set per operation; a migration file is a list of operations applied in
+// order.
+type Operation struct {
+	CreateTable *Table         `yaml:"create_table,omitempty"`
+	DropTable   string         `yaml:"drop_table,omitempty"`
+	CreateIndex *CreateIndexOp `yaml:"create_index,omitempty"`
+	DropIndex   *IndexOp       `yaml:"drop_index,omitempty"`
+	PrimaryKey  *PrimaryKeyOp  `yaml:"primary_key,omitempty"`
+}
+
+// migrationOps is the top-level shape of a single migration YAML file.
+type migrationOps struct {
+	Operations []Operation `yaml:"operations"`
+}
+
+// Migration represents one numbered step in the migrations/ directory, with
+// its up and down operations loaded. LoadMigrations requires both files for
+// every version, so neither side is ever absent.
+type Migration struct {
+	Version int64
+	Up      []Operation
+	Down    []Operation
+}
+
+// LoadMigrations reads dir, a directory of <version>_<name>.up.yaml /
+// <version>_<name>.down.yaml pairs, and returns the migrations sorted by
+// version ascending. It is an error for an up file to exist without a
+// matching down file, since `down N` must always be able to unwind.
+func LoadMigrations(dir string) ([]Migration, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read migrations directory [%s]", dir)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		m := migrationFileRE.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid version in migration file [%s]", e.Name())
+		}
+
+		ops, err := readOperations(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version}
+			byVersion[version] = mig
+		}
+		switch m[2] {
+		case "up":
+			mig.Up = ops
+		case "down":
+			mig.Down = ops
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == nil {
+			return nil, errors.Errorf("migration %d is missing an .up.yaml file", mig.Version)
+		}
+		if mig.Down == nil {
+			return nil, errors.Errorf("migration %d is missing a .down.yaml file", mig.Version)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func readOperations(path string) ([]Operation, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read migration file [%s]", path)
+	}
+	var m migrationOps
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, errors.Wrapf(err, "could not parse migration file [%s]", path)
+	}
+	return m.Operations, nil
+}
+
+// previousVersion returns the version of the migration immediately below v,
+// or 0 if v is the oldest migration.
+func previousVersion(migrations []Migration, v int64) int64 {
+	var prev int64
+	for _, m := range migrations {
+		if m.Version < v && m.Version > prev {
+			prev = m.Version
+		}
+	}
+	return prev
+}
@@ -0,0 +1,74 @@
+package remigrate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeMigrationFile(t *testing.T, dir, name, body string) {
+	t.Helper()
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+		t.Fatalf("could not write migration fixture %s: %v", name, err)
+	}
+}
+
+func TestLoadMigrationsOrdersByVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remigrate-migrations")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeMigrationFile(t, dir, "0002_add_orders.up.yaml", "operations:\n- create_table:\n    name: orders\n")
+	writeMigrationFile(t, dir, "0002_add_orders.down.yaml", "operations:\n- drop_table: orders\n")
+	writeMigrationFile(t, dir, "0001_add_users.up.yaml", "operations:\n- create_table:\n    name: users\n")
+	writeMigrationFile(t, dir, "0001_add_users.down.yaml", "operations:\n- drop_table: users\n")
+
+	migrations, err := LoadMigrations(dir)
+	if err != nil {
+		t.Fatalf("LoadMigrations returned error: %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("expected 2 migrations, got %d", len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[1].Version != 2 {
+		t.Fatalf("expected versions [1, 2], got [%d, %d]", migrations[0].Version, migrations[1].Version)
+	}
+	if migrations[0].Up[0].CreateTable.Name != "users" {
+		t.Fatalf("expected migration 1's up operation to create [users], got %q", migrations[0].Up[0].CreateTable.Name)
+	}
+}
+
+func TestLoadMigrationsMissingDownFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remigrate-migrations")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeMigrationFile(t, dir, "0001_add_users.up.yaml", "operations:\n- create_table:\n    name: users\n")
+
+	if _, err := LoadMigrations(dir); err == nil {
+		t.Fatal("expected an error for a migration missing its down file, got nil")
+	}
+}
+
+func TestPreviousVersion(t *testing.T) {
+	migrations := []Migration{{Version: 1}, {Version: 2}, {Version: 5}}
+
+	cases := []struct {
+		version  int64
+		expected int64
+	}{
+		{version: 5, expected: 2},
+		{version: 2, expected: 1},
+		{version: 1, expected: 0},
+	}
+	for _, c := range cases {
+		if got := previousVersion(migrations, c.version); got != c.expected {
+			t.Errorf("previousVersion(migrations, %d) = %d, want %d", c.version, got, c.expected)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package remigrate
+
+import (
+	"context"
+
+	r "github.com/GoRethink/gorethink"
+	"github.com/pkg/errors"
+)
+
+// Stats reports how many databases, tables, and secondary indexes a
+// Migrator has created over its lifetime.
+type Stats struct {
+	DatabasesCreated int
+	TablesCreated    int
+	IndexesCreated   int
+}
+
+// Migrator drives database/table/index reconciliation and versioned
+// migrations against a single RethinkDB session. Unlike the original
+// package-main implementation, it keeps its counters as instance state
+// rather than globals and never calls log.Fatal, so it can be embedded in a
+// larger program.
+//
+// Session is the session interface, not a concrete *r.Session: every method
+// on Migrator runs terms through it instead of calling gorethink directly,
+// so tests in this package can back Migrator with a fake session instead of
+// a live RethinkDB connection.
+type Migrator struct {
+	Session session
+	Config  *Config
+	Logger  Logger
+
+	stats Stats
+}
+
+// NewMigrator builds a Migrator backed by sess. If logger is nil,
+// StdLogger{} is used.
+func NewMigrator(sess *r.Session, cfg *Config, logger Logger) *Migrator {
+	if logger == nil {
+		logger = StdLogger{}
+	}
+	return &Migrator{Session: rethinkSession{sess}, Config: cfg, Logger: logger}
+}
+
+// Stats returns the counts of databases, tables, and indexes this Migrator
+// has created so far.
+func (m *Migrator) Stats() Stats {
+	return m.stats
+}
+
+// Close releases the underlying RethinkDB connection.
+func (m *Migrator) Close() error {
+	return m.Session.close()
+}
+
+// EnsureDatabase creates m.Config.DBName if it doesn't already exist.
+func (m *Migrator) EnsureDatabase(ctx context.Context) error {
+	ok, err := m.dbExists(ctx, m.Config.DBName)
+	if err != nil {
+		return err
+	}
+	if ok {
+		m.Logger.Printf("[%-30s] %-10s database exists\n", m.Config.DBName, ignore)
+		return nil
+	}
+	if err := m.dbCreate(ctx, m.Config.DBName); err != nil {
+		return err
+	}
+	m.Logger.Printf("[%-30s] %-10s database\n", m.Config.DBName, create)
+	return nil
+}
+
+// Drop drops m.Config.DBName and everything in it.
+func (m *Migrator) Drop(ctx context.Context) error {
+	resp, err := m.Session.runWrite(ctx, r.DBDrop(m.Config.DBName))
+	if err != nil {
+		return errors.Wrapf(err, "failed to drop [%s] database", m.Config.DBName)
+	}
+	m.Logger.Printf("%-3d database dropped\n%-3d table(s) dropped\n", resp.DBsDropped, resp.TablesDropped)
+	return nil
+}
+
+// EnsureTable creates table if it doesn't already exist, waiting for it to
+// become ready across the cluster before returning.
+func (m *Migrator) EnsureTable(ctx context.Context, table Table) error {
+	ok, err := m.tableExists(ctx, table.Name)
+	if err != nil {
+		return err
+	}
+	if ok {
+		m.Logger.Printf("[%-30s] %-10s table exists\n", table.Name, ignore)
+		return nil
+	}
+	return m.tableCreate(ctx, table)
+}
+
+// EnsureIndexes creates every simple and Index entry declared on table that
+// doesn't already exist.
+func (m *Migrator) EnsureIndexes(ctx context.Context, table Table) error {
+	if err := m.addSimpleIndexes(ctx, table); err != nil {
+		return err
+	}
+	return m.addIndexes(ctx, table)
+}
+
+func (m *Migrator) dbExists(ctx context.Context, dbName string) (bool, error) {
+	var dbs []string
+	if err := m.Session.readAll(ctx, r.DBList(), &dbs); err != nil {
+		return false, errors.Wrap(err, "could not list all database names in the system")
+	}
+	for i := range dbs {
+		if dbName == dbs[i] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Migrator) dbCreate(ctx context.Context, dbName string) error {
+	resp, err := m.Session.runWrite(ctx, r.DBCreate(dbName))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create [%s] database", dbName)
+	}
+	// If successful, the command returns an object with two fields, where dbs_created: always 1
+	m.stats.DatabasesCreated += resp.DBsCreated
+	return nil
+}
+
+func (m *Migrator) tableExists(ctx context.Context, tblName string) (bool, error) {
+	var tbls []string
+	if err := m.Session.readAll(ctx, r.TableList(), &tbls); err != nil {
+		return false, errors.Wrap(err, "could not list all table names in database")
+	}
+	for i := range tbls {
+		if tblName == tbls[i] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Migrator) tableCreate(ctx context.Context, table Table) error {
+	opts := new(r.TableCreateOpts)
+	if table.PrimaryKey != "" {
+		opts.PrimaryKey = table.PrimaryKey
+	}
+	if table.Shards > 0 {
+		opts.Shards = table.Shards
+	}
+	if table.Replicas > 0 {
+		opts.Replicas = table.Replicas
+	}
+	if table.PrimaryReplicaTag != "" {
+		opts.PrimaryReplicaTag = table.PrimaryReplicaTag
+	}
+	resp, err := m.Session.runWrite(ctx, r.TableCreate(table.Name, *opts))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create [%s] table", table.Name)
+	}
+	m.stats.TablesCreated += resp.TablesCreated
+
+	// Table creation in RethinkDB is asynchronous across the cluster; Wait
+	// blocks until the table is actually ready to accept reads/writes so
+	// that a subsequent index creation or seed insert doesn't race it.
+	if _, err := m.Session.runWrite(ctx, r.Table(table.Name).Wait()); err != nil {
+		return errors.Wrapf(err, "failed waiting for [%s] table to become ready", table.Name)
+	}
+	m.Logger.Printf("[%-30s] %-10s table\n", table.Name, create)
+	return nil
+}
+
+func (m *Migrator) addSimpleIndexes(ctx context.Context, table Table) error {
+	if len(table.SimpleIndexes) == 0 {
+		return nil
+	}
+
+	lookup, err := m.indexStatus(ctx, table.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range table.SimpleIndexes {
+		if lookup[s] {
+			m.Logger.Printf("[%-30s] %-10s secondary index exists on %s\n", s, ignore, table.Name)
+			continue
+		}
+		if err := m.indexCreate(ctx, table.Name, s); err != nil {
+			return err
+		}
+		m.Logger.Printf("[%-30s] %-10s secondary index on %s\n", s, create, table.Name)
+	}
+	return nil
+}
+
+func (m *Migrator) indexCreate(ctx context.Context, tblname, index string) error {
+	if _, err := m.Session.runWrite(ctx, r.Table(tblname).IndexCreate(index)); err != nil {
+		return errors.Wrapf(err, "failed to create [%v] secondary index on table [%v]", index, tblname)
+	}
+
+	// IndexCreate returns before the index has finished building; IndexWait
+	// blocks until it's ready so callers don't query against a half-built
+	// index.
+	if _, err := m.Session.runWrite(ctx, r.Table(tblname).IndexWait(index)); err != nil {
+		return errors.Wrapf(err, "failed waiting for [%v] secondary index on table [%v] to become ready", index, tblname)
+	}
+	m.stats.IndexesCreated++
+	return nil
+}
@@ -0,0 +1,178 @@
+package remigrate
+
+import (
+	"context"
+	"fmt"
+
+	r "github.com/GoRethink/gorethink"
+)
+
+// Action is a single intended change to the database, carrying both a
+// human-readable description (for --plan output) and the code to actually
+// perform it (for apply). Keeping these together is what lets "decide" and
+// "execute" share one code path.
+type Action struct {
+	Description string
+	Execute     func(ctx context.Context) error
+
+	// Destructive marks actions that remove something not declared in
+	// config (currently: dropping a drifted index) rather than bringing
+	// the cluster in line with something config asks for. Apply does not
+	// run these unless explicitly told to via includeDestructive, the same
+	// way the CLI's --dbdrop requires an explicit flag plus confirmation.
+	Destructive bool
+}
+
+// Plan compares m.Config against the observed state of the cluster and
+// returns the Actions needed to reconcile them, without making any changes.
+// It's the RethinkDB analogue of `terraform plan`.
+func (m *Migrator) Plan(ctx context.Context) ([]Action, error) {
+	var actions []Action
+
+	dbOK, err := m.dbExists(ctx, m.Config.DBName)
+	if err != nil {
+		return nil, err
+	}
+	if !dbOK {
+		actions = append(actions, Action{
+			Description: fmt.Sprintf("CREATE DATABASE %s", m.Config.DBName),
+			Execute: func(ctx context.Context) error {
+				return m.dbCreate(ctx, m.Config.DBName)
+			},
+		})
+	}
+
+	for _, table := range m.Config.DBTables {
+		tableActions, err := m.planTable(ctx, table, dbOK)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, tableActions...)
+	}
+	return actions, nil
+}
+
+func (m *Migrator) planTable(ctx context.Context, table Table, dbOK bool) ([]Action, error) {
+	var actions []Action
+
+	// If the database itself doesn't exist yet, there is nothing to query
+	// for this table: it and every one of its indexes needs to be created.
+	tblExists := false
+	if dbOK {
+		var err error
+		tblExists, err = m.tableExists(ctx, table.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !tblExists {
+		t := table
+		actions = append(actions, Action{
+			Description: describeCreateTable(t),
+			Execute: func(ctx context.Context) error {
+				return m.tableCreate(ctx, t)
+			},
+		})
+		for _, name := range table.SimpleIndexes {
+			name := name
+			actions = append(actions, Action{
+				Description: fmt.Sprintf("CREATE INDEX %s.%s", table.Name, name),
+				Execute: func(ctx context.Context) error {
+					return m.indexCreate(ctx, table.Name, name)
+				},
+			})
+		}
+		for _, idx := range table.Indexes {
+			idx := idx
+			actions = append(actions, Action{
+				Description: fmt.Sprintf("CREATE INDEX %s.%s", table.Name, idx.Name),
+				Execute: func(ctx context.Context) error {
+					return m.indexCreateSpec(ctx, table.Name, idx)
+				},
+			})
+		}
+		return actions, nil
+	}
+
+	existing, err := m.indexStatus(ctx, table.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	declared := make(map[string]bool, len(table.SimpleIndexes)+len(table.Indexes))
+	for _, name := range table.SimpleIndexes {
+		declared[name] = true
+		if !existing[name] {
+			name := name
+			actions = append(actions, Action{
+				Description: fmt.Sprintf("CREATE INDEX %s.%s", table.Name, name),
+				Execute: func(ctx context.Context) error {
+					return m.indexCreate(ctx, table.Name, name)
+				},
+			})
+		}
+	}
+	for _, idx := range table.Indexes {
+		declared[idx.Name] = true
+		if !existing[idx.Name] {
+			idx := idx
+			actions = append(actions, Action{
+				Description: fmt.Sprintf("CREATE INDEX %s.%s", table.Name, idx.Name),
+				Execute: func(ctx context.Context) error {
+					return m.indexCreateSpec(ctx, table.Name, idx)
+				},
+			})
+		}
+	}
+
+	// Drift: indexes that exist in the cluster but aren't declared anywhere
+	// in config. The plan surfaces these as drops so that Apply can
+	// reconcile fully, mirroring terraform's behaviour for resources
+	// removed from config.
+	for name := range existing {
+		if declared[name] {
+			continue
+		}
+		name := name
+		actions = append(actions, Action{
+			Description: fmt.Sprintf("DROP INDEX %s.%s", table.Name, name),
+			Execute: func(ctx context.Context) error {
+				_, err := m.Session.runWrite(ctx, r.Table(table.Name).IndexDrop(name))
+				return err
+			},
+			Destructive: true,
+		})
+	}
+	return actions, nil
+}
+
+func describeCreateTable(t Table) string {
+	primaryKey := t.PrimaryKey
+	if primaryKey == "" {
+		primaryKey = "id"
+	}
+	shards := t.Shards
+	if shards == 0 {
+		shards = 1
+	}
+	return fmt.Sprintf("CREATE TABLE %s (primary_key=%s, shards=%d)", t.Name, primaryKey, shards)
+}
+
+// Apply executes every action in order, stopping at the first error.
+// Destructive actions (see Action.Destructive) are skipped and merely
+// logged unless includeDestructive is true; callers that want to apply
+// them should get explicit operator confirmation first, same as --dbdrop.
+func (m *Migrator) Apply(ctx context.Context, actions []Action, includeDestructive bool) error {
+	for _, a := range actions {
+		if a.Destructive && !includeDestructive {
+			m.Logger.Printf("[%-30s] %-10s (use --prune to apply)\n", a.Description, "skip")
+			continue
+		}
+		if err := a.Execute(ctx); err != nil {
+			return err
+		}
+		m.Logger.Printf("[%-30s] %-10s\n", a.Description, create)
+	}
+	return nil
+}
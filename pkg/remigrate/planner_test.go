@@ -0,0 +1,19 @@
+package remigrate
+
+import "testing"
+
+func TestDescribeCreateTableDefaults(t *testing.T) {
+	got := describeCreateTable(Table{Name: "users"})
+	want := "CREATE TABLE users (primary_key=id, shards=1)"
+	if got != want {
+		t.Errorf("describeCreateTable() = %q, want %q", got, want)
+	}
+}
+
+func TestDescribeCreateTableExplicit(t *testing.T) {
+	got := describeCreateTable(Table{Name: "users", PrimaryKey: "email", Shards: 3})
+	want := "CREATE TABLE users (primary_key=email, shards=3)"
+	if got != want {
+		t.Errorf("describeCreateTable() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,29 @@
+package remigrate
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/go-yaml/yaml"
+)
+
+// ReadConfig reads a Config from the YAML file at path, which may be
+// relative to the current working directory or absolute.
+func ReadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Config
+	if err := yaml.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
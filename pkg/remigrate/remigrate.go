@@ -0,0 +1,9 @@
+package remigrate
+
+// VERSION is the current version git tag.
+var VERSION = "v0.1.0"
+
+const (
+	create = "create"
+	ignore = "ignore"
+)
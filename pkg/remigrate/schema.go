@@ -0,0 +1,135 @@
+package remigrate
+
+import (
+	"context"
+
+	r "github.com/GoRethink/gorethink"
+	"github.com/pkg/errors"
+)
+
+const (
+	schemaMigrationsTable = "schema_migrations"
+	schemaLockTable       = "schema_lock"
+
+	// schemaMigrationsRowID is the single well-known row id that tracks the
+	// current version and dirty state, mirroring golang-migrate's model of
+	// one version row per database rather than one row per applied migration.
+	schemaMigrationsRowID = 1
+
+	// schemaLockRowID is the well-known row inserted to acquire the
+	// migration lock. Insert fails with a duplicate primary key error if the
+	// row already exists, giving us a conditional write without needing a
+	// dedicated RethinkDB locking primitive.
+	schemaLockRowID = "lock"
+)
+
+// schemaVersionRow is the row stored in schema_migrations.
+type schemaVersionRow struct {
+	ID       int    `gorethink:"id"`
+	Version  int64  `gorethink:"version"`
+	Dirty    bool   `gorethink:"dirty"`
+	SeedHash string `gorethink:"seed_hash"`
+}
+
+// EnsureSchemaTables creates the schema_migrations and schema_lock tables if
+// they don't already exist. It must be called before any other migration
+// operation.
+func (m *Migrator) EnsureSchemaTables(ctx context.Context) error {
+	for _, name := range []string{schemaMigrationsTable, schemaLockTable} {
+		ok, err := m.tableExists(ctx, name)
+		if err != nil {
+			return err
+		}
+		if ok {
+			continue
+		}
+		if _, err := m.Session.runWrite(ctx, r.TableCreate(name)); err != nil {
+			return errors.Wrapf(err, "failed to create [%s] table", name)
+		}
+	}
+	return nil
+}
+
+// CurrentVersion returns the current schema version and dirty flag. A
+// version of 0 with no error means no migrations have been applied yet.
+func (m *Migrator) CurrentVersion(ctx context.Context) (int64, bool, error) {
+	var row schemaVersionRow
+	found, err := m.Session.readOne(ctx, r.Table(schemaMigrationsTable).Get(schemaMigrationsRowID), &row)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "could not read schema_migrations row")
+	}
+	if !found {
+		return 0, false, nil
+	}
+	return row.Version, row.Dirty, nil
+}
+
+// SetVersion upserts the schema_migrations row with the given version and
+// dirty flag. Only these two fields are sent, so an Insert conflict of
+// "update" (a merge, not a replace) leaves any recorded SeedHash untouched.
+func (m *Migrator) SetVersion(ctx context.Context, version int64, dirty bool) error {
+	doc := map[string]interface{}{"id": schemaMigrationsRowID, "version": version, "dirty": dirty}
+	if _, err := m.Session.runWrite(ctx, r.Table(schemaMigrationsTable).Insert(doc, r.InsertOpts{Conflict: "update"})); err != nil {
+		return errors.Wrap(err, "could not write schema_migrations row")
+	}
+	return nil
+}
+
+// SeedHash returns the seed_hash recorded on the last successful seed run,
+// or "" if seeding has never run.
+func (m *Migrator) SeedHash(ctx context.Context) (string, error) {
+	var row schemaVersionRow
+	found, err := m.Session.readOne(ctx, r.Table(schemaMigrationsTable).Get(schemaMigrationsRowID), &row)
+	if err != nil {
+		return "", errors.Wrap(err, "could not read schema_migrations row")
+	}
+	if !found {
+		return "", nil
+	}
+	return row.SeedHash, nil
+}
+
+// SetSeedHash records hash as the seed_hash on the schema_migrations row.
+// Only this field is sent, so the merge leaves Version/Dirty untouched.
+func (m *Migrator) SetSeedHash(ctx context.Context, hash string) error {
+	doc := map[string]interface{}{"id": schemaMigrationsRowID, "seed_hash": hash}
+	if _, err := m.Session.runWrite(ctx, r.Table(schemaMigrationsTable).Insert(doc, r.InsertOpts{Conflict: "update"})); err != nil {
+		return errors.Wrap(err, "could not write schema_migrations row")
+	}
+	return nil
+}
+
+// Lock acquires the migration lock by inserting the well-known lock row.
+// RethinkDB rejects an Insert whose primary key already exists, so a
+// concurrent remigrate process attempting to lock fails here instead of
+// racing the migration.
+func (m *Migrator) Lock(ctx context.Context) error {
+	_, err := m.Session.runWrite(ctx, r.Table(schemaLockTable).Insert(map[string]interface{}{"id": schemaLockRowID}))
+	if err != nil {
+		return errors.Wrap(err, "could not acquire migration lock, another remigrate process may be running")
+	}
+	return nil
+}
+
+// Unlock releases the migration lock acquired by Lock.
+func (m *Migrator) Unlock(ctx context.Context) error {
+	_, err := m.Session.runWrite(ctx, r.Table(schemaLockTable).Get(schemaLockRowID).Delete())
+	if err != nil {
+		return errors.Wrap(err, "could not release migration lock")
+	}
+	return nil
+}
+
+// GuardDirty refuses to proceed if the schema is marked dirty, i.e. a
+// previous migration run was interrupted mid-way. Operators must inspect
+// the damage and run Force before remigrate will touch the schema again.
+func (m *Migrator) GuardDirty(ctx context.Context) error {
+	_, dirty, err := m.CurrentVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return errors.New("database is in a dirty state, fix the schema manually and run `remigrate force V`")
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package remigrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	r "github.com/GoRethink/gorethink"
+	"github.com/pkg/errors"
+)
+
+// SeedAll inserts every table's declared Seed fixtures, using upsert
+// semantics keyed on the table's primary key so reruns are safe. If the
+// combined seed content hasn't changed since the last successful seed run
+// (tracked via a hash recorded in schema_migrations), it's skipped
+// entirely.
+func (m *Migrator) SeedAll(ctx context.Context) error {
+	if err := m.EnsureSchemaTables(ctx); err != nil {
+		return err
+	}
+
+	hash, err := seedHash(m.Config.DBTables)
+	if err != nil {
+		return err
+	}
+
+	last, err := m.SeedHash(ctx)
+	if err != nil {
+		return err
+	}
+	if hash != "" && hash == last {
+		m.Logger.Printf("[%-30s] %-10s seed data unchanged\n", "seed", ignore)
+		return nil
+	}
+
+	for _, table := range m.Config.DBTables {
+		if len(table.Seed) == 0 {
+			continue
+		}
+		if err := m.seedTable(ctx, table); err != nil {
+			return err
+		}
+	}
+
+	return m.SetSeedHash(ctx, hash)
+}
+
+func (m *Migrator) seedTable(ctx context.Context, table Table) error {
+	docs := make([]interface{}, len(table.Seed))
+	for i, d := range table.Seed {
+		docs[i] = d
+	}
+	resp, err := m.Session.runWrite(ctx, r.Table(table.Name).Insert(docs, r.InsertOpts{Conflict: "update"}))
+	if err != nil {
+		return errors.Wrapf(err, "failed to seed [%s] table", table.Name)
+	}
+	m.Logger.Printf("[%-30s] %-10s %d seed document(s) (%d inserted, %d updated)\n",
+		table.Name, create, len(table.Seed), resp.Inserted, resp.Replaced)
+	return nil
+}
+
+// seedHash returns a stable hash of every table's seed content, or "" if no
+// table declares any seed data.
+func seedHash(tables []Table) (string, error) {
+	type tableSeed struct {
+		Table string                   `json:"table"`
+		Seed  []map[string]interface{} `json:"seed"`
+	}
+
+	var seeds []tableSeed
+	for _, t := range tables {
+		if len(t.Seed) == 0 {
+			continue
+		}
+		seeds = append(seeds, tableSeed{Table: t.Name, Seed: t.Seed})
+	}
+	if len(seeds) == 0 {
+		return "", nil
+	}
+
+	b, err := json.Marshal(seeds)
+	if err != nil {
+		return "", errors.Wrap(err, "could not hash seed data")
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
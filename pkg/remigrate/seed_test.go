@@ -0,0 +1,45 @@
+package remigrate
+
+import "testing"
+
+func TestSeedHashEmpty(t *testing.T) {
+	hash, err := seedHash([]Table{{Name: "users"}})
+	if err != nil {
+		t.Fatalf("seedHash returned error: %v", err)
+	}
+	if hash != "" {
+		t.Fatalf("expected empty hash for tables with no seed data, got %q", hash)
+	}
+}
+
+func TestSeedHashStableAndSensitiveToContent(t *testing.T) {
+	tables := []Table{{
+		Name: "users",
+		Seed: []map[string]interface{}{{"id": "1", "name": "ada"}},
+	}}
+
+	first, err := seedHash(tables)
+	if err != nil {
+		t.Fatalf("seedHash returned error: %v", err)
+	}
+	if first == "" {
+		t.Fatal("expected a non-empty hash for tables with seed data")
+	}
+
+	second, err := seedHash(tables)
+	if err != nil {
+		t.Fatalf("seedHash returned error: %v", err)
+	}
+	if first != second {
+		t.Fatalf("seedHash is not stable across identical input: %q != %q", first, second)
+	}
+
+	tables[0].Seed[0]["name"] = "grace"
+	changed, err := seedHash(tables)
+	if err != nil {
+		t.Fatalf("seedHash returned error: %v", err)
+	}
+	if changed == first {
+		t.Fatal("expected seedHash to change when seed content changes")
+	}
+}
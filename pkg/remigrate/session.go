@@ -0,0 +1,155 @@
+package remigrate
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"log"
+	"time"
+
+	r "github.com/GoRethink/gorethink"
+	"github.com/pkg/errors"
+)
+
+// defaultConnectRetries and defaultConnectRetryWait are used when a config
+// does not specify its own retry behaviour.
+const (
+	defaultConnectRetries   = 5
+	defaultConnectRetryWait = 2 * time.Second
+)
+
+// session is the subset of RethinkDB behavior Migrator depends on: running
+// a write term and getting back its WriteResponse, or running a read term
+// and decoding its rows. Every method takes the r.Term to run rather than
+// exposing the connection directly, so the decoding (Cursor.IsNil/One/All)
+// lives in one place instead of being repeated at every call site.
+//
+// rethinkSession is the only production implementation, adapting a live
+// *r.Session. Tests in this package substitute a fake that implements
+// session without a RethinkDB connection.
+type session interface {
+	// runWrite runs term and returns its WriteResponse, the same as
+	// term.RunWrite(sess) would against a live connection.
+	runWrite(ctx context.Context, term r.Term) (r.WriteResponse, error)
+	// readOne runs term and decodes its first row into dest, reporting
+	// whether a row was found at all (a miss is not an error).
+	readOne(ctx context.Context, term r.Term, dest interface{}) (bool, error)
+	// readAll runs term and decodes every row into dest, a pointer to a slice.
+	readAll(ctx context.Context, term r.Term, dest interface{}) error
+	// changes runs a Changes() term and hands back the live cursor for the
+	// caller to iterate. Unlike the other methods this isn't a good fit for
+	// a canned fake and is only used by Watch.
+	changes(ctx context.Context, term r.Term) (*r.Cursor, error)
+	// close releases the underlying connection, backing Migrator.Close.
+	close() error
+}
+
+// rethinkSession adapts a *r.Session to the session interface.
+type rethinkSession struct {
+	s *r.Session
+}
+
+func (rs rethinkSession) runWrite(ctx context.Context, term r.Term) (r.WriteResponse, error) {
+	return term.RunWrite(rs.s, r.RunOpts{Context: ctx})
+}
+
+func (rs rethinkSession) readOne(ctx context.Context, term r.Term, dest interface{}) (bool, error) {
+	cur, err := term.Run(rs.s, r.RunOpts{Context: ctx})
+	if err != nil {
+		return false, err
+	}
+	defer cur.Close()
+	if cur.IsNil() {
+		return false, nil
+	}
+	if err := cur.One(dest); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (rs rethinkSession) readAll(ctx context.Context, term r.Term, dest interface{}) error {
+	cur, err := term.Run(rs.s, r.RunOpts{Context: ctx})
+	if err != nil {
+		return err
+	}
+	defer cur.Close()
+	return cur.All(dest)
+}
+
+func (rs rethinkSession) changes(ctx context.Context, term r.Term) (*r.Cursor, error) {
+	return term.Run(rs.s, r.RunOpts{Context: ctx})
+}
+
+func (rs rethinkSession) close() error {
+	return rs.s.Close()
+}
+
+// NewSession builds a gorethink ConnectOpts from the config and connects,
+// retrying with a fixed backoff on failure. A single flaky connection
+// attempt during a RethinkDB rolling restart or proxy failover should not
+// be fatal. ctx bounds the whole retry loop, not just a single attempt.
+func NewSession(ctx context.Context, c *Config) (*r.Session, error) {
+	opts := r.ConnectOpts{
+		Addresses: c.Addresses,
+		AuthKey:   c.AuthKey,
+		Username:  c.Username,
+		Password:  c.Password,
+	}
+	if len(opts.Addresses) == 0 {
+		opts.Address = c.DBIP + ":" + c.DBPort
+	}
+	if c.TLS != nil {
+		tlsConfig, err := loadTLSConfig(c.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig = tlsConfig
+	}
+
+	retries := c.ConnectRetries
+	if retries <= 0 {
+		retries = defaultConnectRetries
+	}
+	wait := defaultConnectRetryWait
+	if c.ConnectRetryWait > 0 {
+		wait = time.Duration(c.ConnectRetryWait) * time.Second
+	}
+
+	var s *r.Session
+	var err error
+	for attempt := 1; attempt <= retries; attempt++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		s, err = r.Connect(opts)
+		if err == nil {
+			r.SetTags("gorethink", "json")
+			return s, nil
+		}
+		if attempt < retries {
+			log.Printf("connect attempt %d/%d to rethinkDB failed: %v, retrying in %s\n", attempt, retries, err, wait)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+	}
+	return nil, errors.Wrapf(err, "error connecting to rethinkDB after %d attempt(s)", retries)
+}
+
+// loadTLSConfig reads the configured CA certificate and builds a tls.Config
+// that verifies the RethinkDB server against it.
+func loadTLSConfig(c *TLSConfig) (*tls.Config, error) {
+	pem, err := ioutil.ReadFile(c.CACertFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read TLS CA certificate")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("could not parse TLS CA certificate")
+	}
+	return &tls.Config{RootCAs: pool}, nil
+}
@@ -0,0 +1,192 @@
+package remigrate
+
+import (
+	"context"
+	"fmt"
+
+	r "github.com/GoRethink/gorethink"
+	p "gopkg.in/gorethink/gorethink.v4/ql2"
+)
+
+// fakeSession is a minimal in-memory session implementation backing unit
+// tests of Lock/Unlock/CurrentVersion/SetVersion/Up/Down/Goto, the methods
+// that actually talk to RethinkDB. Rather than reimplementing gorethink's
+// wire protocol, it walks the generic query tree term.Build() already
+// produces, interpreting the handful of ReQL term types those methods
+// issue (table, get, insert, delete, table_create, table_drop, table_list,
+// wait).
+type fakeSession struct {
+	tables map[string]bool
+	rows   map[string]map[interface{}]map[string]interface{}
+}
+
+func newFakeSession() *fakeSession {
+	return &fakeSession{
+		tables: map[string]bool{},
+		rows:   map[string]map[interface{}]map[string]interface{}{},
+	}
+}
+
+// asTerm decodes a value from term.Build() back into its term type and
+// arguments, or reports isTerm=false for a plain datum (string, number,
+// map, ...).
+func asTerm(v interface{}) (tt p.Term_TermType, args []interface{}, optArgs map[string]interface{}, isTerm bool) {
+	arr, ok := v.([]interface{})
+	if !ok || len(arr) == 0 {
+		return 0, nil, nil, false
+	}
+	code, ok := arr[0].(int)
+	if !ok {
+		return 0, nil, nil, false
+	}
+	tt = p.Term_TermType(code)
+	if len(arr) > 1 {
+		if a, ok := arr[1].([]interface{}); ok {
+			args = a
+		} else if m, ok := arr[1].(map[string]interface{}); ok {
+			optArgs = m
+		}
+	}
+	if len(arr) > 2 {
+		if m, ok := arr[2].(map[string]interface{}); ok {
+			optArgs = m
+		}
+	}
+	return tt, args, optArgs, true
+}
+
+func tableNameOf(v interface{}) (string, bool) {
+	tt, args, _, ok := asTerm(v)
+	if !ok || tt != p.Term_TABLE || len(args) == 0 {
+		return "", false
+	}
+	name, ok := args[0].(string)
+	return name, ok
+}
+
+func (f *fakeSession) runWrite(ctx context.Context, term r.Term) (r.WriteResponse, error) {
+	built, err := term.Build()
+	if err != nil {
+		return r.WriteResponse{}, err
+	}
+	tt, args, optArgs, ok := asTerm(built)
+	if !ok {
+		return r.WriteResponse{}, fmt.Errorf("fakeSession: term did not build to a query tree")
+	}
+
+	switch tt {
+	case p.Term_TABLE_CREATE:
+		name, _ := args[0].(string)
+		f.tables[name] = true
+		return r.WriteResponse{TablesCreated: 1}, nil
+	case p.Term_TABLE_DROP:
+		name, _ := args[0].(string)
+		delete(f.tables, name)
+		delete(f.rows, name)
+		return r.WriteResponse{TablesDropped: 1}, nil
+	case p.Term_WAIT:
+		return r.WriteResponse{}, nil
+	case p.Term_INSERT:
+		table, ok := tableNameOf(args[0])
+		if !ok {
+			return r.WriteResponse{}, fmt.Errorf("fakeSession: insert on a non-table term")
+		}
+		doc, _ := args[1].(map[string]interface{})
+		id := doc["id"]
+		conflict, _ := optArgs["conflict"].(string)
+
+		rows := f.rows[table]
+		if rows == nil {
+			rows = map[interface{}]map[string]interface{}{}
+			f.rows[table] = rows
+		}
+		if existing, found := rows[id]; found {
+			if conflict != "update" {
+				return r.WriteResponse{}, fmt.Errorf("Duplicate primary key `id`")
+			}
+			for k, v := range doc {
+				existing[k] = v
+			}
+			return r.WriteResponse{Replaced: 1}, nil
+		}
+		rows[id] = doc
+		return r.WriteResponse{Inserted: 1}, nil
+	case p.Term_DELETE:
+		getTT, getArgs, _, ok := asTerm(args[0])
+		if !ok || getTT != p.Term_GET {
+			return r.WriteResponse{}, fmt.Errorf("fakeSession: delete on an unsupported term")
+		}
+		table, _ := tableNameOf(getArgs[0])
+		id := getArgs[1]
+		if _, found := f.rows[table][id]; !found {
+			return r.WriteResponse{}, nil
+		}
+		delete(f.rows[table], id)
+		return r.WriteResponse{Deleted: 1}, nil
+	}
+	return r.WriteResponse{}, fmt.Errorf("fakeSession: unsupported term type %v in runWrite", tt)
+}
+
+func (f *fakeSession) readOne(ctx context.Context, term r.Term, dest interface{}) (bool, error) {
+	built, err := term.Build()
+	if err != nil {
+		return false, err
+	}
+	tt, args, _, ok := asTerm(built)
+	if !ok || tt != p.Term_GET {
+		return false, fmt.Errorf("fakeSession: readOne only supports get(), got %v", tt)
+	}
+	table, _ := tableNameOf(args[0])
+	doc, found := f.rows[table][args[1]]
+	if !found {
+		return false, nil
+	}
+
+	row, ok := dest.(*schemaVersionRow)
+	if !ok {
+		return false, fmt.Errorf("fakeSession: readOne only supports *schemaVersionRow, got %T", dest)
+	}
+	switch v := doc["version"].(type) {
+	case int64:
+		row.Version = v
+	case int:
+		row.Version = int64(v)
+	}
+	if v, ok := doc["dirty"].(bool); ok {
+		row.Dirty = v
+	}
+	if v, ok := doc["seed_hash"].(string); ok {
+		row.SeedHash = v
+	}
+	return true, nil
+}
+
+func (f *fakeSession) readAll(ctx context.Context, term r.Term, dest interface{}) error {
+	built, err := term.Build()
+	if err != nil {
+		return err
+	}
+	tt, _, _, ok := asTerm(built)
+	if !ok || tt != p.Term_TABLE_LIST {
+		return fmt.Errorf("fakeSession: readAll only supports table_list(), got %v", tt)
+	}
+	names, ok := dest.(*[]string)
+	if !ok {
+		return fmt.Errorf("fakeSession: readAll only supports *[]string, got %T", dest)
+	}
+	for name := range f.tables {
+		*names = append(*names, name)
+	}
+	return nil
+}
+
+func (f *fakeSession) changes(ctx context.Context, term r.Term) (*r.Cursor, error) {
+	return nil, fmt.Errorf("fakeSession: changes is not supported")
+}
+
+func (f *fakeSession) close() error { return nil }
+
+// nullLogger discards everything, keeping test output quiet.
+type nullLogger struct{}
+
+func (nullLogger) Printf(string, ...interface{}) {}
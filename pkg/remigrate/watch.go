@@ -0,0 +1,68 @@
+package remigrate
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	r "github.com/GoRethink/gorethink"
+	"github.com/pkg/errors"
+)
+
+// changeEvent is one row of a Table.Changes() cursor, reported verbatim as
+// JSON so a human watching a migration land in a staging environment can
+// see exactly what's propagating.
+type changeEvent struct {
+	Table  string      `json:"table"`
+	OldVal interface{} `json:"old_val"`
+	NewVal interface{} `json:"new_val"`
+}
+
+// Watch opens a Changes() cursor on every table in tables and streams each
+// event to w as a JSON line, until ctx is cancelled. It's meant for
+// verifying, in a staging environment, that indexes and replication are
+// actually propagating writes after a migration.
+func (m *Migrator) Watch(ctx context.Context, tables []Table, w io.Writer) error {
+	var wg sync.WaitGroup
+	errs := make(chan error, len(tables))
+
+	for _, table := range tables {
+		table := table
+		cur, err := m.Session.changes(ctx, r.Table(table.Name).Changes())
+		if err != nil {
+			return errors.Wrapf(err, "failed to open changefeed on [%s] table", table.Name)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer cur.Close()
+
+			var raw struct {
+				OldVal interface{} `gorethink:"old_val"`
+				NewVal interface{} `gorethink:"new_val"`
+			}
+			var enc = json.NewEncoder(w)
+			for cur.Next(&raw) {
+				evt := changeEvent{Table: table.Name, OldVal: raw.OldVal, NewVal: raw.NewVal}
+				if err := enc.Encode(evt); err != nil {
+					errs <- err
+					return
+				}
+			}
+			if err := cur.Err(); err != nil && ctx.Err() == nil {
+				errs <- errors.Wrapf(err, "changefeed on [%s] table ended", table.Name)
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}